@@ -0,0 +1,134 @@
+// Copyright (c) 2017 Daniel Joos
+// Distributed under MIT license (see file LICENSE).
+
+// Command docker-credential-winvault implements the Docker credential-helper
+// protocol (https://github.com/docker/docker-credential-helpers) on top of
+// the winvault package's web-credentials vault.
+//
+// Docker (and compatible tools, e.g. git-credential-manager) invoke it as
+// `docker-credential-winvault <store|get|erase|list>`, exchanging JSON over
+// stdin/stdout.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/danieljoos/winvault"
+	"github.com/danieljoos/winvault/pkg/credhelper"
+)
+
+// errNotFoundProtocol is the exact message the credential-helper protocol
+// expects on a "get"/"erase" miss, so that Docker falls back to anonymous
+// access instead of aborting: https://github.com/docker/docker-credential-helpers
+var errNotFoundProtocol = errors.New("credentials not found in native keychain")
+
+// credentials mirrors the JSON shape used by the store/get subcommands of
+// the Docker credential-helper protocol.
+type credentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fail(fmt.Errorf("usage: %s <store|get|erase|list>", os.Args[0]))
+	}
+	vault, err := winvault.OpenWebCredentials()
+	if err != nil {
+		fail(err)
+	}
+	defer vault.Close()
+	store := credhelper.NewStore(vault)
+
+	switch os.Args[1] {
+	case "store":
+		runStore(store)
+	case "get":
+		runGet(store)
+	case "erase":
+		runErase(store)
+	case "list":
+		runList(store)
+	default:
+		fail(fmt.Errorf("unknown command %q", os.Args[1]))
+	}
+}
+
+func runStore(store *credhelper.Store) {
+	var creds credentials
+	if err := json.NewDecoder(os.Stdin).Decode(&creds); err != nil {
+		fail(err)
+	}
+	if err := store.Add(creds.ServerURL, creds.Username, creds.Secret); err != nil {
+		fail(err)
+	}
+}
+
+func runGet(store *credhelper.Store) {
+	serverURL, err := readLine(os.Stdin)
+	if err != nil {
+		fail(err)
+	}
+	username, secret, err := store.Get(serverURL)
+	if err != nil {
+		failLookup(err)
+	}
+	writeJSON(credentials{Username: username, Secret: secret})
+}
+
+func runErase(store *credhelper.Store) {
+	serverURL, err := readLine(os.Stdin)
+	if err != nil {
+		fail(err)
+	}
+	if err := store.Delete(serverURL); err != nil {
+		failLookup(err)
+	}
+}
+
+func runList(store *credhelper.Store) {
+	items, err := store.List()
+	if err != nil {
+		fail(err)
+	}
+	writeJSON(items)
+}
+
+// readLine reads and trims a single line, as sent by Docker for the get/erase
+// subcommands.
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func writeJSON(v interface{}) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// failLookup reports err, translating credhelper.ErrNotFound to
+// errNotFoundProtocol so callers get the sentinel message the protocol
+// expects rather than this package's own error string.
+func failLookup(err error) {
+	if errors.Is(err, credhelper.ErrNotFound) {
+		fail(errNotFoundProtocol)
+		return
+	}
+	fail(err)
+}
@@ -4,10 +4,11 @@
 package winvault
 
 import (
-	"reflect"
 	"time"
 	"unicode/utf16"
 	"unsafe"
+
+	"golang.org/x/sys/windows"
 )
 
 // uf16PtrToString creates a Go string from a pointer to a UTF16 encoded zero-terminated string.
@@ -18,11 +19,7 @@ func utf16PtrToString(wstr *uint16) string {
 		for len := 0; ; len++ {
 			ptr := unsafe.Pointer(uintptr(unsafe.Pointer(wstr)) + uintptr(len)*unsafe.Sizeof(*wstr)) // see https://golang.org/pkg/unsafe/#Pointer (3)
 			if *(*uint16)(ptr) == 0 {
-				return string(utf16.Decode(*(*[]uint16)(unsafe.Pointer(&reflect.SliceHeader{
-					Data: uintptr(unsafe.Pointer(wstr)),
-					Len:  len,
-					Cap:  len,
-				}))))
+				return string(utf16.Decode(unsafe.Slice(wstr, len)))
 			}
 		}
 	}
@@ -36,38 +33,134 @@ func goBytes(src uintptr, len uint32) []byte {
 		return []byte{}
 	}
 	rv := make([]byte, len)
-	copy(rv, *(*[]byte)(unsafe.Pointer(&reflect.SliceHeader{
-		Data: src,
-		Len:  int(len),
-		Cap:  int(len),
-	})))
+	copy(rv, unsafe.Slice((*byte)(unsafe.Pointer(src)), len))
 	return rv
 }
 
+// copySid duplicates the given SID into newly allocated Go memory, so that
+// the original - which may point into a buffer the Windows Vault API frees
+// right after conversion - can be dropped safely. Returns nil for a nil sid.
+func copySid(sid *windows.SID) *windows.SID {
+	if sid == nil {
+		return nil
+	}
+	b := make([]byte, sid.Len())
+	copy(b, unsafe.Slice((*byte)(unsafe.Pointer(sid)), len(b)))
+	return (*windows.SID)(unsafe.Pointer(&b[0]))
+}
+
 // convertToVaultItemElement converts the given sysVaultElement to a structure
 // more usable in golang. All values are copied and the sysVaultElement can
 // therefore be freed afterwards.
 // The function distinguishes between different types of vault-item elements.
-// For unsupported types the function returns nil.
+// Attribute and protected-array elements, which have no dedicated Go
+// representation yet, are returned as VaultItemElementAny. For a nil
+// sysVaultElement, or one of an unknown type, the function returns nil.
 func convertToVaultItemElement(elem *sysVaultElement) VaultItemElement {
-	if elem != nil {
-		switch elem.Type {
-		case vaultElementTypeString:
-			return &VaultItemElementString{
-				id:    elem.ID,
-				value: utf16PtrToString((*sysVaultElementString)(unsafe.Pointer(elem)).Data),
-			}
-		case vaultElementTypeByteArray:
-			elemBA := (*sysVaultElementByteArray)(unsafe.Pointer(elem))
-			return &VaultItemElementByteArray{
-				id:    elem.ID,
-				value: goBytes(elemBA.Value, elemBA.Length),
-			}
+	if elem == nil {
+		return nil
+	}
+	switch elem.Type {
+	case vaultElementTypeString:
+		return &VaultItemElementString{
+			id:    elem.ID,
+			value: utf16PtrToString((*sysVaultElementString)(unsafe.Pointer(elem)).Data),
+		}
+	case vaultElementTypeByteArray:
+		elemBA := (*sysVaultElementByteArray)(unsafe.Pointer(elem))
+		return &VaultItemElementByteArray{
+			id:    elem.ID,
+			value: goBytes(elemBA.Value, elemBA.Length),
+		}
+	case vaultElementTypeBoolean:
+		return &VaultItemElementBool{
+			id:    elem.ID,
+			value: (*sysVaultElementBoolean)(unsafe.Pointer(elem)).Value != 0,
+		}
+	case vaultElementTypeShort:
+		return &VaultItemElementInt32{
+			id:    elem.ID,
+			value: int32((*sysVaultElementShort)(unsafe.Pointer(elem)).Value),
+		}
+	case vaultElementTypeInteger:
+		return &VaultItemElementInt32{
+			id:    elem.ID,
+			value: (*sysVaultElementInteger)(unsafe.Pointer(elem)).Value,
+		}
+	case vaultElementTypeUnsignedShort:
+		return &VaultItemElementUint32{
+			id:    elem.ID,
+			value: uint32((*sysVaultElementUnsignedShort)(unsafe.Pointer(elem)).Value),
+		}
+	case vaultElementTypeUnsignedInteger:
+		return &VaultItemElementUint32{
+			id:    elem.ID,
+			value: (*sysVaultElementUnsignedInteger)(unsafe.Pointer(elem)).Value,
+		}
+	case vaultElementTypeDouble:
+		return &VaultItemElementFloat64{
+			id:    elem.ID,
+			value: (*sysVaultElementDouble)(unsafe.Pointer(elem)).Value,
+		}
+	case vaultElementTypeGUID:
+		return &VaultItemElementGUID{
+			id:    elem.ID,
+			value: (*sysVaultElementGUID)(unsafe.Pointer(elem)).Value,
+		}
+	case vaultElementTypeTimeStamp:
+		elemTS := (*sysVaultElementTimeStamp)(unsafe.Pointer(elem))
+		return &VaultItemElementTime{
+			id:    elem.ID,
+			value: time.Unix(0, elemTS.Value.Nanoseconds()),
+		}
+	case vaultElementTypeSid:
+		elemSid := (*sysVaultElementSid)(unsafe.Pointer(elem))
+		return &VaultItemElementSID{
+			id:    elem.ID,
+			value: copySid((*windows.SID)(unsafe.Pointer(elemSid.Value))),
+		}
+	case vaultElementTypeAttribute:
+		elemAttr := (*sysVaultElementAttribute)(unsafe.Pointer(elem))
+		return &VaultItemElementAny{
+			id:      elem.ID,
+			typ:     ElementTypeAttribute,
+			rawData: goBytes(elemAttr.Value, elemAttr.Length),
+		}
+	case vaultElementTypeProtectedArray:
+		elemPA := (*sysVaultElementProtectedArray)(unsafe.Pointer(elem))
+		return &VaultItemElementAny{
+			id:      elem.ID,
+			typ:     ElementTypeProtectedArray,
+			rawData: goBytes(elemPA.Value, elemPA.Length),
 		}
 	}
 	return nil
 }
 
+// sysVaultElementSize is the fixed size, in bytes, of a single VAULT_ITEM_ELEMENT
+// entry in a Properties array: the sysVaultElement header followed by its
+// 16-byte union tail (see the sysVaultElementXxx types in syscall.go).
+const sysVaultElementSize = unsafe.Sizeof(sysVaultElement{}) + 16
+
+// convertToVaultProperties walks the Properties array of a sysVaultItem7 or
+// sysVaultItem8 - unlike Resource/Identity/Authenticator, which point at
+// individually allocated elements, Properties is a contiguous array of
+// sysVaultElementSize-sized entries - and converts every entry. Entries that
+// convertToVaultItemElement turns into nil are left out of the result.
+func convertToVaultProperties(base uintptr, count uint32) []VaultItemElement {
+	if base == 0 || count == 0 {
+		return nil
+	}
+	properties := make([]VaultItemElement, 0, count)
+	for i := uint32(0); i < count; i++ {
+		elem := (*sysVaultElement)(unsafe.Pointer(base + uintptr(i)*sysVaultElementSize))
+		if converted := convertToVaultItemElement(elem); converted != nil {
+			properties = append(properties, converted)
+		}
+	}
+	return properties
+}
+
 // convertToVaultItem7 implements the vault-item conversion for the Windows 7
 // version of the Vault API.
 func convertToVaultItem7(item *sysVaultItem7) *VaultItem {
@@ -77,6 +170,7 @@ func convertToVaultItem7(item *sysVaultItem7) *VaultItem {
 		Resource:      convertToVaultItemElement(item.Resource),
 		Identity:      convertToVaultItemElement(item.Identity),
 		Authenticator: convertToVaultItemElement(item.Authenticator),
+		Properties:    convertToVaultProperties(item.Properties, item.PropertiesCount),
 		LastModified:  time.Unix(0, item.Filetime.Nanoseconds()),
 	}
 }
@@ -90,6 +184,79 @@ func convertToVaultItem8(item *sysVaultItem8) *VaultItem {
 		Resource:      convertToVaultItemElement(item.Resource),
 		Identity:      convertToVaultItemElement(item.Identity),
 		Authenticator: convertToVaultItemElement(item.Authenticator),
+		Properties:    convertToVaultProperties(item.Properties, item.PropertiesCount),
 		LastModified:  time.Unix(0, item.Filetime.Nanoseconds()),
 	}
 }
+
+// vaultItemPins keeps the Go buffers that back a marshaled sysVaultItem alive
+// for the duration of a syscall. The CLR only sees the raw pointers embedded
+// in the sysVaultItem7/sysVaultItem8 structures, so nothing else keeps these
+// buffers referenced; without this, the garbage collector would be free to
+// reclaim them before the syscall completes.
+type vaultItemPins struct {
+	name          []uint16
+	resource      interface{}
+	identity      interface{}
+	authenticator interface{}
+}
+
+// convertFromVaultItemElement converts the given VaultItemElement to its
+// sysVaultElement representation. It returns the marshaled element together
+// with the buffer that backs it; the caller must keep that buffer alive
+// (e.g. via runtime.KeepAlive) for as long as the returned pointer is passed
+// to the Windows Vault API. Only string and byte-array elements are
+// marshaled; for a nil element or one of any other type it returns nil, nil.
+func convertFromVaultItemElement(elem VaultItemElement) (*sysVaultElement, interface{}) {
+	if elem == nil {
+		return nil, nil
+	}
+	switch elem.Type() {
+	case ElementTypeString:
+		buf := utf16.Encode([]rune(elem.AsString() + "\x00"))
+		sysElem := &sysVaultElementString{
+			sysVaultElement: sysVaultElement{ID: elem.ID(), Type: vaultElementTypeString},
+			Data:            &buf[0],
+		}
+		return &sysElem.sysVaultElement, buf
+	case ElementTypeByteArray:
+		buf := elem.AsByteArray()
+		sysElem := &sysVaultElementByteArray{
+			sysVaultElement: sysVaultElement{ID: elem.ID(), Type: vaultElementTypeByteArray},
+			Length:          uint32(len(buf)),
+		}
+		if len(buf) > 0 {
+			sysElem.Value = uintptr(unsafe.Pointer(&buf[0]))
+		}
+		return &sysElem.sysVaultElement, buf
+	}
+	return nil, nil
+}
+
+// convertFromVaultItem7 marshals the given VaultItem to its Windows 7
+// sysVaultItem7 representation, to be passed to VaultAddItem/VaultSetItem.
+// The returned pins value must be kept alive for as long as the returned
+// sysVaultItem7 (and the pointers therein) are in use.
+func convertFromVaultItem7(item VaultItem) (*sysVaultItem7, *vaultItemPins) {
+	sysItem := &sysVaultItem7{ID: item.ID}
+	pins := &vaultItemPins{name: utf16.Encode([]rune(item.Name + "\x00"))}
+	sysItem.Name = &pins.name[0]
+	sysItem.Resource, pins.resource = convertFromVaultItemElement(item.Resource)
+	sysItem.Identity, pins.identity = convertFromVaultItemElement(item.Identity)
+	sysItem.Authenticator, pins.authenticator = convertFromVaultItemElement(item.Authenticator)
+	return sysItem, pins
+}
+
+// convertFromVaultItem8 marshals the given VaultItem to its Windows 8
+// sysVaultItem8 representation, to be passed to VaultAddItem/VaultSetItem.
+// The returned pins value must be kept alive for as long as the returned
+// sysVaultItem8 (and the pointers therein) are in use.
+func convertFromVaultItem8(item VaultItem) (*sysVaultItem8, *vaultItemPins) {
+	sysItem := &sysVaultItem8{ID: item.ID}
+	pins := &vaultItemPins{name: utf16.Encode([]rune(item.Name + "\x00"))}
+	sysItem.Name = &pins.name[0]
+	sysItem.Resource, pins.resource = convertFromVaultItemElement(item.Resource)
+	sysItem.Identity, pins.identity = convertFromVaultItemElement(item.Identity)
+	sysItem.Authenticator, pins.authenticator = convertFromVaultItemElement(item.Authenticator)
+	return sysItem, pins
+}
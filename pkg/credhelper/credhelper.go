@@ -0,0 +1,117 @@
+// Copyright (c) 2017 Daniel Joos
+// Distributed under MIT license (see file LICENSE).
+
+// Package credhelper maps the Docker credential-helper protocol
+// (https://github.com/docker/docker-credential-helpers) onto winvault vault
+// items, so that protocol adapters - such as cmd/docker-credential-winvault,
+// and other tools like git-credential-manager - don't need to reimplement
+// the mapping between a server URL/username/secret triple and a
+// winvault.VaultItem.
+package credhelper
+
+import (
+	"errors"
+
+	"github.com/danieljoos/winvault"
+)
+
+// Name is the friendly name set on vault items created by this package, so
+// that List and Delete only ever touch items owned by this helper.
+const Name = "docker-credential-winvault"
+
+// ErrNotFound is returned by Store.Get and Store.Delete when no credential
+// is stored for the given server URL.
+var ErrNotFound = errors.New("credhelper: credential not found")
+
+// Store maps credential-helper operations onto vault items of an open
+// winvault.Vault, typically the web-credentials vault opened via
+// winvault.OpenWebCredentials.
+type Store struct {
+	vault *winvault.Vault
+}
+
+// NewStore creates a new Store backed by the given, already opened vault.
+func NewStore(vault *winvault.Vault) *Store {
+	return &Store{vault: vault}
+}
+
+// Add stores the given credential, overwriting any credential already
+// stored for serverURL - the credential-helper protocol's "store" verb is an
+// upsert. Resource is set to serverURL, Identity to username and
+// Authenticator to secret; Name is set to Name so the item can be found
+// again by List, Get and Delete.
+func (s *Store) Add(serverURL, username, secret string) error {
+	item := winvault.VaultItem{
+		Name:          Name,
+		Resource:      winvault.NewStringElement(0, serverURL),
+		Identity:      winvault.NewStringElement(0, username),
+		Authenticator: winvault.NewStringElement(0, secret),
+	}
+	existing, err := s.find(serverURL)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	if existing != nil {
+		item.ID = existing.ID
+		return s.vault.Set(item)
+	}
+	return s.vault.Add(item)
+}
+
+// Get returns the username and secret stored for the given server URL.
+// It returns ErrNotFound if no matching credential exists, or if the
+// matching item is missing its Identity or Authenticator element.
+func (s *Store) Get(serverURL string) (username string, secret string, err error) {
+	item, err := s.find(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+	if item.Identity == nil || item.Authenticator == nil {
+		return "", "", ErrNotFound
+	}
+	return item.Identity.AsString(), item.Authenticator.AsString(), nil
+}
+
+// Delete removes the credential stored for the given server URL.
+// It returns ErrNotFound if no matching credential exists.
+func (s *Store) Delete(serverURL string) error {
+	item, err := s.find(serverURL)
+	if err != nil {
+		return err
+	}
+	return s.vault.Remove(item.ID)
+}
+
+// List returns the server URLs owned by this helper, mapped to their
+// username, as required by the "list" subcommand of the credential-helper
+// protocol.
+func (s *Store) List() (map[string]string, error) {
+	items, err := s.vault.Items()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	for _, item := range items {
+		if item.Name != Name || item.Resource == nil || item.Identity == nil {
+			continue
+		}
+		result[item.Resource.AsString()] = item.Identity.AsString()
+	}
+	return result, nil
+}
+
+// find looks up the vault item owned by this helper for the given server
+// URL.
+func (s *Store) find(serverURL string) (*winvault.VaultItem, error) {
+	items, err := s.vault.Items()
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		item := &items[i]
+		if item.Name == Name && item.Resource != nil && item.Resource.AsString() == serverURL {
+			return item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
@@ -4,7 +4,7 @@
 package winvault
 
 import (
-	"reflect"
+	"runtime"
 	"syscall"
 	"unsafe"
 
@@ -13,19 +13,28 @@ import (
 	"github.com/google/uuid"
 )
 
-var (
-	vaultcli = syscall.NewLazyDLL("vaultcli.dll")
+//go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go syscall.go
 
-	procVaultCloseVault      = vaultcli.NewProc("VaultCloseVault")
-	procVaultEnumerateItems  = vaultcli.NewProc("VaultEnumerateItems")
-	procVaultEnumerateVaults = vaultcli.NewProc("VaultEnumerateVaults")
-	procVaultFree            = vaultcli.NewProc("VaultFree")
-	procVaultGetInformation  = vaultcli.NewProc("VaultGetInformation")
-	procVaultGetItem         = vaultcli.NewProc("VaultGetItem")
-	procVaultOpenVault       = vaultcli.NewProc("VaultOpenVault")
+// VaultAddItem, VaultGetItem and VaultSetItem take one fewer argument on
+// Windows 7 than on Windows 8 and above - the PackageSid slot is absent from
+// the Windows 7 export entirely, not just always zero. Passing the Windows 8
+// arg count on Windows 7 shifts every following argument by one slot, so
+// each gets its own //sys entry with the arity-correct parameter list.
+//sys vaultAddItem7(vault windows.Handle, schemaID *uuid.UUID, resource *sysVaultElement, identity *sysVaultElement, authenticator *sysVaultElement, unknown0 uint32, unknown1 uint32) (errno error) = vaultcli.VaultAddItem
+//sys vaultAddItem8(vault windows.Handle, schemaID *uuid.UUID, resource *sysVaultElement, identity *sysVaultElement, authenticator *sysVaultElement, packageSid *windows.SID, unknown0 uint32, unknown1 uint32) (errno error) = vaultcli.VaultAddItem
+//sys vaultCloseVault(vault windows.Handle) (errno error) = vaultcli.VaultCloseVault
+//sys vaultEnumerateItems(vault windows.Handle, unknown0 uint32, count *uint32, items *uintptr) (errno error) = vaultcli.VaultEnumerateItems
+//sys vaultEnumerateVaults(unknown0 uint32, count *uint32, guids *uintptr) (errno error) = vaultcli.VaultEnumerateVaults
+//sys vaultFree(mem uintptr) (errno error) = vaultcli.VaultFree
+//sys vaultGetInformation(vault windows.Handle, unknown0 uint32, info *sysVaultInformationString) (errno error) = vaultcli.VaultGetInformation
+//sys vaultGetItem7(vault windows.Handle, schemaID *uuid.UUID, resource *sysVaultElement, identity *sysVaultElement, unknown0 uint32, unknown1 uint32, item *uintptr) (errno error) = vaultcli.VaultGetItem
+//sys vaultGetItem8(vault windows.Handle, schemaID *uuid.UUID, resource *sysVaultElement, identity *sysVaultElement, packageSid *windows.SID, unknown0 uint32, unknown1 uint32, item *uintptr) (errno error) = vaultcli.VaultGetItem
+//sys vaultOpenVault(vaultGUID *uuid.UUID, unknown0 uint32, vault *windows.Handle) (errno error) = vaultcli.VaultOpenVault
+//sys vaultRemoveItem(vault windows.Handle, itemID *uuid.UUID) (errno error) = vaultcli.VaultRemoveItem
+//sys vaultSetItem7(vault windows.Handle, itemID *uuid.UUID, resource *sysVaultElement, identity *sysVaultElement, authenticator *sysVaultElement, unknown0 uint32, unknown1 uint32) (errno error) = vaultcli.VaultSetItem
+//sys vaultSetItem8(vault windows.Handle, itemID *uuid.UUID, resource *sysVaultElement, identity *sysVaultElement, authenticator *sysVaultElement, packageSid *windows.SID, unknown0 uint32, unknown1 uint32) (errno error) = vaultcli.VaultSetItem
 
-	useAPI7 = isWindows7()
-)
+var useAPI7 = isWindows7()
 
 type sysVaultInformationKind uint32
 type sysVaultElementType int32
@@ -75,6 +84,68 @@ type sysVaultElementByteArray struct {
 	Value  uintptr
 }
 
+// The following types cover the remaining variants of the VAULT_ITEM_ELEMENT
+// union. Real VAULT_ITEM_ELEMENT values only have a single 16-byte tail after
+// the sysVaultElement header, whose interpretation depends on Type - these
+// types each cast that tail to the shape appropriate for their variant.
+
+type sysVaultElementBoolean struct {
+	sysVaultElement
+	Value int32
+}
+
+type sysVaultElementShort struct {
+	sysVaultElement
+	Value int16
+}
+
+type sysVaultElementUnsignedShort struct {
+	sysVaultElement
+	Value uint16
+}
+
+type sysVaultElementInteger struct {
+	sysVaultElement
+	Value int32
+}
+
+type sysVaultElementUnsignedInteger struct {
+	sysVaultElement
+	Value uint32
+}
+
+type sysVaultElementDouble struct {
+	sysVaultElement
+	Value float64
+}
+
+type sysVaultElementGUID struct {
+	sysVaultElement
+	Value uuid.UUID
+}
+
+type sysVaultElementTimeStamp struct {
+	sysVaultElement
+	Value syscall.Filetime
+}
+
+type sysVaultElementSid struct {
+	sysVaultElement
+	Value uintptr
+}
+
+type sysVaultElementAttribute struct {
+	sysVaultElement
+	Length uint32
+	Value  uintptr
+}
+
+type sysVaultElementProtectedArray struct {
+	sysVaultElement
+	Length uint32
+	Value  uintptr
+}
+
 type sysVaultItem7 struct {
 	ID              uuid.UUID
 	Name            *uint16
@@ -93,7 +164,7 @@ type sysVaultItem8 struct {
 	Resource        *sysVaultElement
 	Identity        *sysVaultElement
 	Authenticator   *sysVaultElement
-	PackageSid      uintptr
+	PackageSid      *windows.SID
 	Filetime        syscall.Filetime
 	Flags           uint32
 	PropertiesCount uint32
@@ -111,22 +182,13 @@ func isWindows7() bool {
 // sysVaultEnumerateVaults calls the 'VaultEnumerateVaults' function of the
 // Windows Vault API.
 func sysVaultEnumerateVaults() ([]uuid.UUID, error) {
-	var count int
+	var count uint32
 	var guids uintptr
-	ret, _, _ := procVaultEnumerateVaults.Call(
-		0,
-		uintptr(unsafe.Pointer(&count)),
-		uintptr(unsafe.Pointer(&guids)),
-	)
-	if ret != windows.NO_ERROR {
-		return nil, (syscall.Errno)(ret)
+	if err := vaultEnumerateVaults(0, &count, &guids); err != nil {
+		return nil, err
 	}
-	defer procVaultFree.Call(guids)
-	guidsSlice := *(*[]uuid.UUID)(unsafe.Pointer(&reflect.SliceHeader{
-		Data: guids,
-		Len:  count,
-		Cap:  count,
-	}))
+	defer vaultFree(guids)
+	guidsSlice := unsafe.Slice((*uuid.UUID)(unsafe.Pointer(guids)), count)
 	result := make([]uuid.UUID, count)
 	copy(result, guidsSlice)
 	return result, nil
@@ -134,129 +196,268 @@ func sysVaultEnumerateVaults() ([]uuid.UUID, error) {
 
 // sysVaultOpenVault calls the 'VaultOpenVault' function of the Windows Vault
 // API.
-func sysVaultOpenVault(id uuid.UUID) (syscall.Handle, error) {
-	var handle syscall.Handle
-	ret, _, _ := procVaultOpenVault.Call(
-		uintptr(unsafe.Pointer(&id)),
-		0,
-		uintptr(unsafe.Pointer(&handle)),
-	)
-	if ret != windows.NO_ERROR {
-		return 0, (syscall.Errno)(ret)
+func sysVaultOpenVault(id uuid.UUID) (windows.Handle, error) {
+	var handle windows.Handle
+	if err := vaultOpenVault(&id, 0, &handle); err != nil {
+		return 0, err
 	}
 	return handle, nil
 }
 
 // sysVaultCloseVault calls the 'VaultCloseVault' function of the Windows Vault
 // API.
-func sysVaultCloseVault(handle syscall.Handle) error {
-	ret, _, _ := procVaultCloseVault.Call(
-		uintptr(handle),
-	)
-	if ret != windows.NO_ERROR {
-		return (syscall.Errno)(ret)
-	}
-	return nil
+func sysVaultCloseVault(handle windows.Handle) error {
+	return vaultCloseVault(handle)
 }
 
 // sysVaultGetInformationString calls the 'VaultGetInformation' function of the
 // Windows Vault API and returns the result as string.
-func sysVaultGetInformationString(handle syscall.Handle, kind sysVaultInformationKind) (string, error) {
+func sysVaultGetInformationString(handle windows.Handle, kind sysVaultInformationKind) (string, error) {
 	info := sysVaultInformationString{Kind: kind}
-	ret, _, _ := procVaultGetInformation.Call(
-		uintptr(handle),
-		0,
-		uintptr(unsafe.Pointer(&info)),
-	)
-	if ret != windows.NO_ERROR {
-		return "", (syscall.Errno)(ret)
+	if err := vaultGetInformation(handle, 0, &info); err != nil {
+		return "", err
 	}
 	return utf16PtrToString(info.Value), nil
 }
 
 // sysVaultEnumerateItems calls the 'VaultEnumerateItems' function of the
 // Windows Vault API. For each item it calls the sysVaultGetItem function.
-func sysVaultEnumerateItems(handle syscall.Handle) ([]VaultItem, error) {
-	var count int
-	var items uintptr
-	ret, _, _ := procVaultEnumerateItems.Call(
-		uintptr(handle),
-		0,
-		uintptr(unsafe.Pointer(&count)),
-		uintptr(unsafe.Pointer(&items)),
-	)
-	if ret != windows.NO_ERROR {
-		return nil, (syscall.Errno)(ret)
-	}
-	defer procVaultFree.Call(items)
+func sysVaultEnumerateItems(handle windows.Handle) ([]VaultItem, error) {
 	var result []VaultItem
+	appendItem := func(item VaultItem) bool {
+		result = append(result, item)
+		return true
+	}
+	var err error
 	if useAPI7 {
-		// Windows 7: Fetch all vault-items
-		itemsSlice := *(*[]sysVaultItem7)(unsafe.Pointer(&reflect.SliceHeader{
-			Data: items,
-			Len:  count,
-			Cap:  count,
-		}))
-		for _, descriptor := range itemsSlice {
-			item, err := sysVaultGetItem7(handle, descriptor)
-			if err == nil {
-				result = append(result, *item)
+		err = sysVaultEnumerateItemDescriptors7(handle, func(descriptor sysVaultItem7) bool {
+			item, itemErr := sysVaultGetItem7(handle, descriptor)
+			if itemErr != nil {
+				return true
 			}
-		}
+			return appendItem(*item)
+		})
 	} else {
-		// Windows 8 (and above): Fetch all vault-items
-		itemsSlice := *(*[]sysVaultItem8)(unsafe.Pointer(&reflect.SliceHeader{
-			Data: items,
-			Len:  count,
-			Cap:  count,
-		}))
-		for _, descriptor := range itemsSlice {
-			item, err := sysVaultGetItem8(handle, descriptor)
-			if err == nil {
-				result = append(result, *item)
+		err = sysVaultEnumerateItemDescriptors8(handle, func(descriptor sysVaultItem8) bool {
+			item, itemErr := sysVaultGetItem8(handle, descriptor)
+			if itemErr != nil {
+				return true
 			}
-		}
+			return appendItem(*item)
+		})
+	}
+	if err != nil {
+		return nil, err
 	}
 	return result, nil
 }
 
+// sysVaultEnumerateItemDescriptors7 calls the 'VaultEnumerateItems' function
+// of the Windows Vault API for Windows 7 and invokes fn with each raw item
+// descriptor, before any Authenticator element is decrypted. Iteration stops
+// as soon as fn returns false. The underlying buffer is freed before this
+// function returns.
+func sysVaultEnumerateItemDescriptors7(handle windows.Handle, fn func(sysVaultItem7) bool) error {
+	var count uint32
+	var items uintptr
+	if err := vaultEnumerateItems(handle, 0, &count, &items); err != nil {
+		return err
+	}
+	defer vaultFree(items)
+	itemsSlice := unsafe.Slice((*sysVaultItem7)(unsafe.Pointer(items)), count)
+	for _, descriptor := range itemsSlice {
+		if !fn(descriptor) {
+			break
+		}
+	}
+	return nil
+}
+
+// sysVaultEnumerateItemDescriptors8 calls the 'VaultEnumerateItems' function
+// of the Windows Vault API for Windows 8 and above and invokes fn with each
+// raw item descriptor, before any Authenticator element is decrypted.
+// Iteration stops as soon as fn returns false. The underlying buffer is
+// freed before this function returns.
+func sysVaultEnumerateItemDescriptors8(handle windows.Handle, fn func(sysVaultItem8) bool) error {
+	var count uint32
+	var items uintptr
+	if err := vaultEnumerateItems(handle, 0, &count, &items); err != nil {
+		return err
+	}
+	defer vaultFree(items)
+	itemsSlice := unsafe.Slice((*sysVaultItem8)(unsafe.Pointer(items)), count)
+	for _, descriptor := range itemsSlice {
+		if !fn(descriptor) {
+			break
+		}
+	}
+	return nil
+}
+
 // sysVaultGetItem7 calls the 'VaultGetItem' function of the Windows Vault API
 // for Windows 7. The result is converted to a more usable structure.
-func sysVaultGetItem7(handle syscall.Handle, descriptor sysVaultItem7) (*VaultItem, error) {
+func sysVaultGetItem7(handle windows.Handle, descriptor sysVaultItem7) (*VaultItem, error) {
 	var item *sysVaultItem7
-	ret, _, _ := procVaultGetItem.Call(
-		uintptr(handle),
-		uintptr(unsafe.Pointer(&descriptor.ID)),
-		uintptr(unsafe.Pointer(descriptor.Resource)),
-		uintptr(unsafe.Pointer(descriptor.Identity)),
+	err := vaultGetItem7(
+		handle,
+		&descriptor.ID,
+		descriptor.Resource,
+		descriptor.Identity,
 		0,
 		0,
-		uintptr(unsafe.Pointer(&item)),
+		(*uintptr)(unsafe.Pointer(&item)),
 	)
-	if ret != windows.NO_ERROR {
-		return nil, (syscall.Errno)(ret)
+	if err != nil {
+		return nil, err
 	}
-	defer procVaultFree.Call(uintptr(unsafe.Pointer(item)))
+	defer vaultFree(uintptr(unsafe.Pointer(item)))
 	return convertToVaultItem7(item), nil
 }
 
 // sysVaultGetItem8 calls the 'VaultGetItem' function of the Windows Vault API
 // for Windows 8 and above.  The result is converted to a more usable structure.
-func sysVaultGetItem8(handle syscall.Handle, descriptor sysVaultItem8) (*VaultItem, error) {
+func sysVaultGetItem8(handle windows.Handle, descriptor sysVaultItem8) (*VaultItem, error) {
 	var item *sysVaultItem8
-	ret, _, _ := procVaultGetItem.Call(
-		uintptr(handle),
-		uintptr(unsafe.Pointer(&descriptor.ID)),
-		uintptr(unsafe.Pointer(descriptor.Resource)),
-		uintptr(unsafe.Pointer(descriptor.Identity)),
+	err := vaultGetItem8(
+		handle,
+		&descriptor.ID,
+		descriptor.Resource,
+		descriptor.Identity,
 		descriptor.PackageSid,
 		0,
 		0,
-		uintptr(unsafe.Pointer(&item)),
+		(*uintptr)(unsafe.Pointer(&item)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer vaultFree(uintptr(unsafe.Pointer(item)))
+	return convertToVaultItem8(item), nil
+}
+
+// sysVaultAddItem7 calls the 'VaultAddItem' function of the Windows Vault API
+// for Windows 7.
+func sysVaultAddItem7(handle windows.Handle, item VaultItem) error {
+	sysItem, pins := convertFromVaultItem7(item)
+	err := vaultAddItem7(
+		handle,
+		nil,
+		sysItem.Resource,
+		sysItem.Identity,
+		sysItem.Authenticator,
+		0,
+		0,
+	)
+	runtime.KeepAlive(sysItem)
+	runtime.KeepAlive(pins)
+	return err
+}
+
+// sysVaultAddItem8 calls the 'VaultAddItem' function of the Windows Vault API
+// for Windows 8 and above.
+func sysVaultAddItem8(handle windows.Handle, item VaultItem) error {
+	sysItem, pins := convertFromVaultItem8(item)
+	err := vaultAddItem8(
+		handle,
+		nil,
+		sysItem.Resource,
+		sysItem.Identity,
+		sysItem.Authenticator,
+		nil,
+		0,
+		0,
+	)
+	runtime.KeepAlive(sysItem)
+	runtime.KeepAlive(pins)
+	return err
+}
+
+// sysVaultSetItem7 calls the 'VaultSetItem' function of the Windows Vault API
+// for Windows 7.
+func sysVaultSetItem7(handle windows.Handle, item VaultItem) error {
+	sysItem, pins := convertFromVaultItem7(item)
+	err := vaultSetItem7(
+		handle,
+		&sysItem.ID,
+		sysItem.Resource,
+		sysItem.Identity,
+		sysItem.Authenticator,
+		0,
+		0,
+	)
+	runtime.KeepAlive(sysItem)
+	runtime.KeepAlive(pins)
+	return err
+}
+
+// sysVaultSetItem8 calls the 'VaultSetItem' function of the Windows Vault API
+// for Windows 8 and above.
+func sysVaultSetItem8(handle windows.Handle, item VaultItem) error {
+	sysItem, pins := convertFromVaultItem8(item)
+	err := vaultSetItem8(
+		handle,
+		&sysItem.ID,
+		sysItem.Resource,
+		sysItem.Identity,
+		sysItem.Authenticator,
+		sysItem.PackageSid,
+		0,
+		0,
+	)
+	runtime.KeepAlive(sysItem)
+	runtime.KeepAlive(pins)
+	return err
+}
+
+// sysVaultRemoveItem calls the 'VaultRemoveItem' function of the Windows
+// Vault API to remove the item with the given ID.
+func sysVaultRemoveItem(handle windows.Handle, id uuid.UUID) error {
+	return vaultRemoveItem(handle, &id)
+}
+
+// sysVaultGetItemByElements7 calls the 'VaultGetItem' function of the
+// Windows Vault API for Windows 7, using the given Resource and Identity
+// element pointers as the lookup key instead of a previously enumerated
+// descriptor. This is the only way to fetch an item whose Authenticator
+// requires a credential prompt.
+func sysVaultGetItemByElements7(handle windows.Handle, resource, identity *sysVaultElement) (*VaultItem, error) {
+	var item *sysVaultItem7
+	err := vaultGetItem7(
+		handle,
+		nil,
+		resource,
+		identity,
+		0,
+		0,
+		(*uintptr)(unsafe.Pointer(&item)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer vaultFree(uintptr(unsafe.Pointer(item)))
+	return convertToVaultItem7(item), nil
+}
+
+// sysVaultGetItemByElements8 calls the 'VaultGetItem' function of the
+// Windows Vault API for Windows 8 and above, using the given Resource and
+// Identity element pointers as the lookup key instead of a previously
+// enumerated descriptor. This is the only way to fetch an item whose
+// Authenticator requires a credential prompt.
+func sysVaultGetItemByElements8(handle windows.Handle, resource, identity *sysVaultElement) (*VaultItem, error) {
+	var item *sysVaultItem8
+	err := vaultGetItem8(
+		handle,
+		nil,
+		resource,
+		identity,
+		nil,
+		0,
+		0,
+		(*uintptr)(unsafe.Pointer(&item)),
 	)
-	if ret != windows.NO_ERROR {
-		return nil, (syscall.Errno)(ret)
+	if err != nil {
+		return nil, err
 	}
-	defer procVaultFree.Call(uintptr(unsafe.Pointer(item)))
+	defer vaultFree(uintptr(unsafe.Pointer(item)))
 	return convertToVaultItem8(item), nil
 }
@@ -4,8 +4,15 @@
 package winvault
 
 import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
 
 	"github.com/google/uuid"
 )
@@ -19,7 +26,26 @@ type Vault struct {
 	Name string
 	Path string
 
-	handle syscall.Handle
+	handle windows.Handle
+}
+
+// ItemFilter selects vault items by matching against their Resource,
+// Identity and Name fields. A zero-value ItemFilter matches every item;
+// setting a field narrows the match to items whose corresponding field
+// contains that value. See Vault.Find.
+type ItemFilter struct {
+	Resource string
+	Identity string
+	Name     string
+}
+
+// matches reports whether the given item descriptor fields satisfy this
+// filter. An empty matcher field always matches; a non-empty one requires
+// the field it targets to contain it.
+func (f ItemFilter) matches(resource, identity, name string) bool {
+	return (f.Resource == "" || strings.Contains(resource, f.Resource)) &&
+		(f.Identity == "" || strings.Contains(identity, f.Identity)) &&
+		(f.Name == "" || strings.Contains(name, f.Name))
 }
 
 // VaultItem represents a credential item in a vault.
@@ -33,12 +59,16 @@ type Vault struct {
 // the user name in case of web-credentials.
 // The Authenticator property holds the actual credential secret - for web-
 // credentials this would be the password.
+// The Properties slice holds additional, item-specific metadata elements
+// beyond Resource, Identity and Authenticator, e.g. the URL scheme or the
+// favorite flag stored by web-credentials and RDP vaults.
 type VaultItem struct {
 	ID            uuid.UUID
 	Name          string
 	Resource      VaultItemElement
 	Identity      VaultItemElement
 	Authenticator VaultItemElement
+	Properties    []VaultItemElement
 	LastModified  time.Time
 }
 
@@ -46,7 +76,10 @@ type VaultItem struct {
 // Such elements can be of different types and therefore this interface defines
 // a method for getting the actual type of the element.
 // The actual values can be fetched using accessor methods for the different
-// types. For now, the element types 'string' and 'byte-array' are supported.
+// types, e.g. AsString and AsByteArray, which every element implements.
+// Elements of a more specific type additionally implement a typed accessor,
+// such as AsBool or AsInt32 - use Type() to tell which one applies, or a type
+// switch/assertion on the concrete VaultItemElementXxx type.
 type VaultItemElement interface {
 	ID() int32
 	Type() ElementType
@@ -68,6 +101,68 @@ type VaultItemElementByteArray struct {
 	value []byte
 }
 
+// VaultItemElementBool implements the VaultItemElement interface for elements
+// of type boolean.
+type VaultItemElementBool struct {
+	id    int32
+	value bool
+}
+
+// VaultItemElementInt32 implements the VaultItemElement interface for
+// elements of type short and integer. Short elements are sign-extended to
+// int32.
+type VaultItemElementInt32 struct {
+	id    int32
+	value int32
+}
+
+// VaultItemElementUint32 implements the VaultItemElement interface for
+// elements of type unsigned-short and unsigned-integer. Unsigned-short
+// elements are zero-extended to uint32.
+type VaultItemElementUint32 struct {
+	id    int32
+	value uint32
+}
+
+// VaultItemElementFloat64 implements the VaultItemElement interface for
+// elements of type double.
+type VaultItemElementFloat64 struct {
+	id    int32
+	value float64
+}
+
+// VaultItemElementGUID implements the VaultItemElement interface for elements
+// of type GUID.
+type VaultItemElementGUID struct {
+	id    int32
+	value uuid.UUID
+}
+
+// VaultItemElementTime implements the VaultItemElement interface for elements
+// of type timestamp.
+type VaultItemElementTime struct {
+	id    int32
+	value time.Time
+}
+
+// VaultItemElementSID implements the VaultItemElement interface for elements
+// of type SID (security identifier).
+type VaultItemElementSID struct {
+	id    int32
+	value *windows.SID
+}
+
+// VaultItemElementAny implements the VaultItemElement interface as a fallback
+// for element types that don't (yet) have a dedicated Go representation, such
+// as attribute and protected-array elements. It exposes the element's raw,
+// unparsed bytes so that callers are not blocked on a typed accessor being
+// added for that ElementType.
+type VaultItemElementAny struct {
+	id      int32
+	typ     ElementType
+	rawData []byte
+}
+
 // ElementType is an enumeration used to distinguish the types of vault-item
 // elements.
 type ElementType int
@@ -78,8 +173,53 @@ const (
 
 	// ElementTypeByteArray corresponds to byte-array elements.
 	ElementTypeByteArray
+
+	// ElementTypeBool corresponds to boolean elements.
+	ElementTypeBool
+
+	// ElementTypeInt32 corresponds to short and integer elements.
+	ElementTypeInt32
+
+	// ElementTypeUint32 corresponds to unsigned-short and unsigned-integer
+	// elements.
+	ElementTypeUint32
+
+	// ElementTypeFloat64 corresponds to double elements.
+	ElementTypeFloat64
+
+	// ElementTypeGUID corresponds to GUID elements.
+	ElementTypeGUID
+
+	// ElementTypeTime corresponds to timestamp elements.
+	ElementTypeTime
+
+	// ElementTypeSID corresponds to SID (security identifier) elements.
+	ElementTypeSID
+
+	// ElementTypeAttribute corresponds to attribute elements. There is no
+	// dedicated accessor for this type yet; use VaultItemElementAny.
+	ElementTypeAttribute
+
+	// ElementTypeProtectedArray corresponds to protected-array elements.
+	// There is no dedicated accessor for this type yet; use
+	// VaultItemElementAny.
+	ElementTypeProtectedArray
 )
 
+// NewStringElement creates a new VaultItemElement of type string, with the
+// given element ID and value. This is useful for building VaultItem values
+// to pass to Vault.Add or Vault.Set.
+func NewStringElement(id int32, s string) VaultItemElement {
+	return &VaultItemElementString{id: id, value: s}
+}
+
+// NewByteArrayElement creates a new VaultItemElement of type byte-array, with
+// the given element ID and value. This is useful for building VaultItem
+// values to pass to Vault.Add or Vault.Set.
+func NewByteArrayElement(id int32, b []byte) VaultItemElement {
+	return &VaultItemElementByteArray{id: id, value: b}
+}
+
 // Type always returns ElementTypeString.
 func (t *VaultItemElementString) Type() ElementType {
 	return ElementTypeString
@@ -119,3 +259,224 @@ func (t *VaultItemElementByteArray) AsString() string {
 func (t *VaultItemElementByteArray) AsByteArray() []byte {
 	return t.value
 }
+
+// Type always returns ElementTypeBool.
+func (t *VaultItemElementBool) Type() ElementType {
+	return ElementTypeBool
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementBool) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's boolean value.
+func (t *VaultItemElementBool) AsString() string {
+	return strconv.FormatBool(t.value)
+}
+
+// AsByteArray returns the byte representation of the element's boolean value.
+func (t *VaultItemElementBool) AsByteArray() []byte {
+	if t.value {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// AsBool returns the element's boolean value.
+func (t *VaultItemElementBool) AsBool() bool {
+	return t.value
+}
+
+// Type always returns ElementTypeInt32.
+func (t *VaultItemElementInt32) Type() ElementType {
+	return ElementTypeInt32
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementInt32) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's int32 value.
+func (t *VaultItemElementInt32) AsString() string {
+	return strconv.FormatInt(int64(t.value), 10)
+}
+
+// AsByteArray returns the little-endian byte representation of the element's
+// int32 value.
+func (t *VaultItemElementInt32) AsByteArray() []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(t.value))
+	return b
+}
+
+// AsInt32 returns the element's int32 value.
+func (t *VaultItemElementInt32) AsInt32() int32 {
+	return t.value
+}
+
+// Type always returns ElementTypeUint32.
+func (t *VaultItemElementUint32) Type() ElementType {
+	return ElementTypeUint32
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementUint32) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's uint32 value.
+func (t *VaultItemElementUint32) AsString() string {
+	return strconv.FormatUint(uint64(t.value), 10)
+}
+
+// AsByteArray returns the little-endian byte representation of the element's
+// uint32 value.
+func (t *VaultItemElementUint32) AsByteArray() []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, t.value)
+	return b
+}
+
+// AsUint32 returns the element's uint32 value.
+func (t *VaultItemElementUint32) AsUint32() uint32 {
+	return t.value
+}
+
+// Type always returns ElementTypeFloat64.
+func (t *VaultItemElementFloat64) Type() ElementType {
+	return ElementTypeFloat64
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementFloat64) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's float64 value.
+func (t *VaultItemElementFloat64) AsString() string {
+	return strconv.FormatFloat(t.value, 'g', -1, 64)
+}
+
+// AsByteArray returns the little-endian byte representation of the element's
+// float64 value.
+func (t *VaultItemElementFloat64) AsByteArray() []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, math.Float64bits(t.value))
+	return b
+}
+
+// AsFloat64 returns the element's float64 value.
+func (t *VaultItemElementFloat64) AsFloat64() float64 {
+	return t.value
+}
+
+// Type always returns ElementTypeGUID.
+func (t *VaultItemElementGUID) Type() ElementType {
+	return ElementTypeGUID
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementGUID) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's GUID value.
+func (t *VaultItemElementGUID) AsString() string {
+	return t.value.String()
+}
+
+// AsByteArray returns the element's GUID value as raw bytes.
+func (t *VaultItemElementGUID) AsByteArray() []byte {
+	return t.value[:]
+}
+
+// AsGUID returns the element's GUID value.
+func (t *VaultItemElementGUID) AsGUID() uuid.UUID {
+	return t.value
+}
+
+// Type always returns ElementTypeTime.
+func (t *VaultItemElementTime) Type() ElementType {
+	return ElementTypeTime
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementTime) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's timestamp
+// value, formatted as RFC 3339.
+func (t *VaultItemElementTime) AsString() string {
+	return t.value.Format(time.RFC3339)
+}
+
+// AsByteArray returns the little-endian byte representation of the element's
+// timestamp value, as Windows FILETIME ticks since 1601-01-01.
+func (t *VaultItemElementTime) AsByteArray() []byte {
+	ft := syscall.NsecToFiletime(t.value.UnixNano())
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b, ft.LowDateTime)
+	binary.LittleEndian.PutUint32(b[4:], ft.HighDateTime)
+	return b
+}
+
+// AsTime returns the element's timestamp value.
+func (t *VaultItemElementTime) AsTime() time.Time {
+	return t.value
+}
+
+// Type always returns ElementTypeSID.
+func (t *VaultItemElementSID) Type() ElementType {
+	return ElementTypeSID
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementSID) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's SID value,
+// e.g. "S-1-5-21-...".
+func (t *VaultItemElementSID) AsString() string {
+	if t.value == nil {
+		return ""
+	}
+	return t.value.String()
+}
+
+// AsByteArray returns the element's SID value as raw bytes.
+func (t *VaultItemElementSID) AsByteArray() []byte {
+	if t.value == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(t.value)), int(t.value.Len()))
+}
+
+// AsSID returns the element's SID value.
+func (t *VaultItemElementSID) AsSID() *windows.SID {
+	return t.value
+}
+
+// Type returns the underlying ElementType this element was created with,
+// e.g. ElementTypeAttribute or ElementTypeProtectedArray.
+func (t *VaultItemElementAny) Type() ElementType {
+	return t.typ
+}
+
+// ID returns the element ID.
+func (t *VaultItemElementAny) ID() int32 {
+	return t.id
+}
+
+// AsString returns the string representation of the element's raw bytes.
+func (t *VaultItemElementAny) AsString() string {
+	return string(t.rawData)
+}
+
+// AsByteArray returns the element's raw, unparsed bytes.
+func (t *VaultItemElementAny) AsByteArray() []byte {
+	return t.rawData
+}
@@ -6,8 +6,6 @@
 // Windows credential vaults. For example this includes the web-credentials vault
 // that is used by Internet Explorer and Edge to store login form information.
 //
-// At the moment, the package provides read-only access to the vault data.
-//
 // As the Windows Vault API is not officially supported nor documented, the main
 // concepts and function signatures have been taken from the following sources:
 //
@@ -18,7 +16,9 @@
 package winvault
 
 import (
-	"syscall"
+	"runtime"
+
+	"golang.org/x/sys/windows"
 
 	"github.com/google/uuid"
 )
@@ -86,7 +86,7 @@ func List() ([]uuid.UUID, error) {
 // vault object will fail.
 func (t *Vault) Close() {
 	sysVaultCloseVault(t.handle)
-	t.handle = syscall.InvalidHandle
+	t.handle = windows.InvalidHandle
 }
 
 // Items returns the credential items of this vault.
@@ -99,3 +99,113 @@ func (t *Vault) Close() {
 func (t *Vault) Items() ([]VaultItem, error) {
 	return sysVaultEnumerateItems(t.handle)
 }
+
+// Add adds the given item to this vault.
+// The item's Resource, Identity and Authenticator elements are marshaled and
+// passed to the Windows Vault API. The item's ID is ignored; the vault
+// assigns a new one. The item's Name is not passed to the API either.
+func (t *Vault) Add(item VaultItem) error {
+	if useAPI7 {
+		return sysVaultAddItem7(t.handle, item)
+	}
+	return sysVaultAddItem8(t.handle, item)
+}
+
+// Set updates an existing item of this vault.
+// The item is looked up by its ID; its Resource, Identity and Authenticator
+// elements are replaced with the ones given.
+func (t *Vault) Set(item VaultItem) error {
+	if useAPI7 {
+		return sysVaultSetItem7(t.handle, item)
+	}
+	return sysVaultSetItem8(t.handle, item)
+}
+
+// Remove removes the item with the given ID from this vault.
+func (t *Vault) Remove(id uuid.UUID) error {
+	return sysVaultRemoveItem(t.handle, id)
+}
+
+// Iterate calls fn once for each item of this vault, fetching (and
+// decrypting) one item at a time instead of materializing the whole vault
+// up front like Items does. Iteration stops as soon as fn returns false, or
+// once every item has been visited.
+func (t *Vault) Iterate(fn func(VaultItem) bool) error {
+	return vaultIterateItems(t.handle, nil, fn)
+}
+
+// Find returns all items of this vault that match the given filter.
+// Unlike Items, Find only fetches (and decrypts) items whose descriptor -
+// already available before decryption - satisfies the filter.
+func (t *Vault) Find(filter ItemFilter) ([]VaultItem, error) {
+	var result []VaultItem
+	err := vaultIterateItems(t.handle, &filter, func(item VaultItem) bool {
+		result = append(result, item)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetItem fetches a single item directly via the Windows Vault API's lookup
+// path, using the given Resource and Identity elements as the key, instead
+// of enumerating all items first. This is the only way to fetch an item
+// whose Authenticator requires a credential prompt.
+func (t *Vault) GetItem(resource, identity VaultItemElement) (*VaultItem, error) {
+	sysResource, resourcePin := convertFromVaultItemElement(resource)
+	sysIdentity, identityPin := convertFromVaultItemElement(identity)
+	var item *VaultItem
+	var err error
+	if useAPI7 {
+		item, err = sysVaultGetItemByElements7(t.handle, sysResource, sysIdentity)
+	} else {
+		item, err = sysVaultGetItemByElements8(t.handle, sysResource, sysIdentity)
+	}
+	runtime.KeepAlive(resourcePin)
+	runtime.KeepAlive(identityPin)
+	return item, err
+}
+
+// vaultIterateItems enumerates the items of the vault with the given handle,
+// calling fn for each one that satisfies filter. A nil filter matches every
+// item. Items whose descriptor doesn't satisfy filter are skipped without
+// fetching (and decrypting) them.
+func vaultIterateItems(handle windows.Handle, filter *ItemFilter, fn func(VaultItem) bool) error {
+	if useAPI7 {
+		return sysVaultEnumerateItemDescriptors7(handle, func(descriptor sysVaultItem7) bool {
+			if filter != nil && !descriptorMatchesFilter(*filter, descriptor.Name, descriptor.Resource, descriptor.Identity) {
+				return true
+			}
+			item, err := sysVaultGetItem7(handle, descriptor)
+			if err != nil {
+				return true
+			}
+			return fn(*item)
+		})
+	}
+	return sysVaultEnumerateItemDescriptors8(handle, func(descriptor sysVaultItem8) bool {
+		if filter != nil && !descriptorMatchesFilter(*filter, descriptor.Name, descriptor.Resource, descriptor.Identity) {
+			return true
+		}
+		item, err := sysVaultGetItem8(handle, descriptor)
+		if err != nil {
+			return true
+		}
+		return fn(*item)
+	})
+}
+
+// descriptorMatchesFilter reports whether the given, not yet decrypted item
+// descriptor fields satisfy filter.
+func descriptorMatchesFilter(filter ItemFilter, name *uint16, resource, identity *sysVaultElement) bool {
+	var resourceStr, identityStr string
+	if elem := convertToVaultItemElement(resource); elem != nil {
+		resourceStr = elem.AsString()
+	}
+	if elem := convertToVaultItemElement(identity); elem != nil {
+		identityStr = elem.AsString()
+	}
+	return filter.matches(resourceStr, identityStr, utf16PtrToString(name))
+}